@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration allows alert rule "for"/"repeat_interval" fields to be
+// authored as human strings ("30s", "5m") in JSON or YAML, instead of
+// raw nanoseconds.
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// AlertRule is one threshold evaluated against the sliding window in
+// statsHistory, e.g. "cpu_percent > 80 for 30s".
+type AlertRule struct {
+	Name           string   `json:"name" yaml:"name"`
+	Metric         string   `json:"metric" yaml:"metric"` // cpu_percent | memory_mb
+	Comparison     string   `json:"comparison" yaml:"comparison"` // ">" or "<"
+	Threshold      float64  `json:"threshold" yaml:"threshold"`
+	For            Duration `json:"for" yaml:"for"`
+	RepeatInterval Duration `json:"repeat_interval" yaml:"repeat_interval"`
+	ProcessName    string   `json:"process_name,omitempty" yaml:"process_name,omitempty"`
+}
+
+// FiringAlert is a rule currently in breach for a specific PID.
+type FiringAlert struct {
+	Rule          string    `json:"rule"`
+	PID           int32     `json:"pid"`
+	ProcessName   string    `json:"process_name"`
+	Metric        string    `json:"metric"`
+	Value         float64   `json:"value"`
+	Threshold     float64   `json:"threshold"`
+	FiringSince   time.Time `json:"firing_since"`
+	LastNotified  time.Time `json:"last_notified"`
+}
+
+// Notifier delivers a firing alert somewhere actionable.
+type Notifier interface {
+	Notify(alert FiringAlert)
+}
+
+// logNotifier is always active as a baseline sink.
+type logNotifier struct{}
+
+func (logNotifier) Notify(alert FiringAlert) {
+	log.Printf("ALERT firing: rule=%s pid=%d process=%s metric=%s value=%.2f threshold=%.2f",
+		alert.Rule, alert.PID, alert.ProcessName, alert.Metric, alert.Value, alert.Threshold)
+}
+
+// webhookNotifier POSTs the firing alert as JSON to a configured URL.
+type webhookNotifier struct {
+	url string
+}
+
+func (w webhookNotifier) Notify(alert FiringAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("webhookNotifier: failed to marshal alert: %v", err)
+		return
+	}
+	resp, err := http.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("webhookNotifier: failed to deliver alert: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// termuxNotifier surfaces alerts as a native Termux notification.
+type termuxNotifier struct{}
+
+func (termuxNotifier) Notify(alert FiringAlert) {
+	title := fmt.Sprintf("%s: %s", alert.Rule, alert.ProcessName)
+	content := fmt.Sprintf("%s=%.2f (threshold %.2f) on PID %d", alert.Metric, alert.Value, alert.Threshold, alert.PID)
+	if err := exec.Command("termux-notification", "-t", title, "-c", content).Run(); err != nil {
+		log.Printf("termuxNotifier: failed to send notification: %v", err)
+	}
+}
+
+var (
+	alertRules   []AlertRule
+	alertRulesMu sync.RWMutex
+
+	activeAlerts   = make(map[string]*FiringAlert)
+	activeAlertsMu sync.RWMutex
+
+	notifiers []Notifier
+)
+
+// loadAlertRules reads rules from a YAML or JSON file, picked by
+// extension, so a deployment can use whichever it prefers.
+func loadAlertRules(path string) ([]AlertRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("alerts: read %s: %w", path, err)
+	}
+	var rules []AlertRule
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rules)
+	default:
+		err = json.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("alerts: parse %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// configureNotifiers wires up notifier backends from environment
+// configuration. The log sink is always included.
+func configureNotifiers() []Notifier {
+	backends := []Notifier{logNotifier{}}
+	if url := os.Getenv("MONITOR_ALERT_WEBHOOK_URL"); url != "" {
+		backends = append(backends, webhookNotifier{url: url})
+	}
+	if os.Getenv("MONITOR_ALERT_TERMUX_NOTIFY") == "true" {
+		backends = append(backends, termuxNotifier{})
+	}
+	return backends
+}
+
+// setAlertRules replaces the active rule set.
+func setAlertRules(rules []AlertRule) {
+	alertRulesMu.Lock()
+	defer alertRulesMu.Unlock()
+	alertRules = rules
+}
+
+// addAlertRules appends to the active rule set, used by POST /rules.
+func addAlertRules(rules []AlertRule) {
+	alertRulesMu.Lock()
+	defer alertRulesMu.Unlock()
+	alertRules = append(alertRules, rules...)
+}
+
+// evaluateRule computes the metric's current value from history and how
+// long it has continuously been in breach of the rule. There is
+// deliberately no network-rate metric here: gopsutil exposes network
+// counters only host-wide, not per-process (see SystemStats.Interfaces
+// in system.go), so a per-PID rate rule would fire identically for every
+// monitored process regardless of which one actually did the networking.
+func evaluateRule(rule AlertRule, history []ResourceStats) (value float64, breachSince time.Time, breaching bool) {
+	if len(history) == 0 {
+		return 0, time.Time{}, false
+	}
+
+	metricAt := func(i int) (float64, bool) {
+		switch rule.Metric {
+		case "cpu_percent":
+			return history[i].CPUPercent, true
+		case "memory_mb":
+			return history[i].MemoryMB, true
+		default:
+			return 0, false
+		}
+	}
+
+	breaches := func(v float64) bool {
+		switch rule.Comparison {
+		case "<":
+			return v < rule.Threshold
+		default:
+			return v > rule.Threshold
+		}
+	}
+
+	latest, ok := metricAt(len(history) - 1)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	if !breaches(latest) {
+		return latest, time.Time{}, false
+	}
+
+	since := history[len(history)-1].Timestamp
+	for i := len(history) - 1; i >= 0; i-- {
+		v, ok := metricAt(i)
+		if !ok || !breaches(v) {
+			break
+		}
+		since = history[i].Timestamp
+	}
+	return latest, since, true
+}
+
+// alertEvaluator periodically evaluates every rule against every
+// monitored PID, firing/de-duplicating/repeating notifications per the
+// rule's "for" and "repeat_interval" settings.
+func alertEvaluator() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		alertRulesMu.RLock()
+		rules := append([]AlertRule(nil), alertRules...)
+		alertRulesMu.RUnlock()
+		if len(rules) == 0 {
+			continue
+		}
+
+		statsMutex.RLock()
+		histories := make(map[int32][]ResourceStats, len(statsHistory))
+		for pid, h := range statsHistory {
+			histories[pid] = h
+		}
+		statsMutex.RUnlock()
+
+		now := time.Now()
+		for _, rule := range rules {
+			for pid, history := range histories {
+				if rule.ProcessName != "" && len(history) > 0 && history[len(history)-1].ProcessName != rule.ProcessName {
+					continue
+				}
+				key := rule.Name + ":" + strconv.Itoa(int(pid))
+				value, since, breaching := evaluateRule(rule, history)
+
+				activeAlertsMu.Lock()
+				existing := activeAlerts[key]
+				if !breaching {
+					delete(activeAlerts, key)
+					activeAlertsMu.Unlock()
+					continue
+				}
+				if now.Sub(since) < time.Duration(rule.For) {
+					activeAlertsMu.Unlock()
+					continue
+				}
+
+				alert := FiringAlert{
+					Rule:        rule.Name,
+					PID:         pid,
+					ProcessName: history[len(history)-1].ProcessName,
+					Metric:      rule.Metric,
+					Value:       value,
+					Threshold:   rule.Threshold,
+					FiringSince: since,
+				}
+				shouldNotify := existing == nil
+				if existing != nil && rule.RepeatInterval > 0 && now.Sub(existing.LastNotified) >= time.Duration(rule.RepeatInterval) {
+					shouldNotify = true
+				}
+				if shouldNotify {
+					alert.LastNotified = now
+				} else {
+					alert.LastNotified = existing.LastNotified
+				}
+				activeAlerts[key] = &alert
+				activeAlertsMu.Unlock()
+
+				if shouldNotify {
+					for _, n := range notifiers {
+						n.Notify(alert)
+					}
+				}
+			}
+		}
+	}
+}
+
+// rulesHandler accepts POST bodies of one or more AlertRule as JSON,
+// appending them to the active rule set.
+func rulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		alertRulesMu.RLock()
+		defer alertRulesMu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alertRules)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var rules []AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+		http.Error(w, fmt.Sprintf("invalid rules payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	addAlertRules(rules)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func alertsHandler(w http.ResponseWriter, r *http.Request) {
+	activeAlertsMu.RLock()
+	defer activeAlertsMu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activeAlerts)
+}
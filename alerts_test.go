@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateRule(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(offset time.Duration, cpu, mem float64) ResourceStats {
+		return ResourceStats{Timestamp: base.Add(offset), CPUPercent: cpu, MemoryMB: mem}
+	}
+
+	tests := []struct {
+		name            string
+		rule            AlertRule
+		history         []ResourceStats
+		wantValue       float64
+		wantBreaching   bool
+		wantBreachSince time.Time
+	}{
+		{
+			name:          "empty history never breaches",
+			rule:          AlertRule{Metric: "cpu_percent", Comparison: ">", Threshold: 80},
+			history:       nil,
+			wantValue:     0,
+			wantBreaching: false,
+		},
+		{
+			name: "cpu_percent under threshold does not breach",
+			rule: AlertRule{Metric: "cpu_percent", Comparison: ">", Threshold: 80},
+			history: []ResourceStats{
+				sample(0, 50, 0),
+			},
+			wantValue:     50,
+			wantBreaching: false,
+		},
+		{
+			name: "cpu_percent over threshold breaches since first consecutive sample",
+			rule: AlertRule{Metric: "cpu_percent", Comparison: ">", Threshold: 80},
+			history: []ResourceStats{
+				sample(0, 50, 0),
+				sample(5*time.Second, 90, 0),
+				sample(10*time.Second, 95, 0),
+			},
+			wantValue:       95,
+			wantBreaching:   true,
+			wantBreachSince: base.Add(5 * time.Second),
+		},
+		{
+			name: "breach streak resets when it dips back under threshold",
+			rule: AlertRule{Metric: "cpu_percent", Comparison: ">", Threshold: 80},
+			history: []ResourceStats{
+				sample(0, 90, 0),
+				sample(5*time.Second, 10, 0),
+				sample(10*time.Second, 95, 0),
+			},
+			wantValue:       95,
+			wantBreaching:   true,
+			wantBreachSince: base.Add(10 * time.Second),
+		},
+		{
+			name: "memory_mb uses < comparison",
+			rule: AlertRule{Metric: "memory_mb", Comparison: "<", Threshold: 100},
+			history: []ResourceStats{
+				sample(0, 0, 50),
+			},
+			wantValue:       50,
+			wantBreaching:   true,
+			wantBreachSince: base,
+		},
+		{
+			name: "unknown metric never breaches",
+			rule: AlertRule{Metric: "network_rx_rate", Comparison: ">", Threshold: 1},
+			history: []ResourceStats{
+				sample(0, 0, 0),
+				sample(5*time.Second, 0, 0),
+			},
+			wantValue:     0,
+			wantBreaching: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, since, breaching := evaluateRule(tt.rule, tt.history)
+			if value != tt.wantValue {
+				t.Errorf("value = %v, want %v", value, tt.wantValue)
+			}
+			if breaching != tt.wantBreaching {
+				t.Errorf("breaching = %v, want %v", breaching, tt.wantBreaching)
+			}
+			if tt.wantBreaching && !since.Equal(tt.wantBreachSince) {
+				t.Errorf("breachSince = %v, want %v", since, tt.wantBreachSince)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore is the optional BoltDB-backed Store, for deployments that
+// want a single embedded file without SQLite's WAL/journal files. It
+// has no minute/hour rollup tiers like sqliteStore does — every sample
+// is kept at full resolution until it falls out of hourRetention, at
+// which point the compactor deletes it outright instead of downsampling it.
+type boltStore struct {
+	db *bolt.DB
+}
+
+var samplesBucket = []byte("samples")
+
+func newBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("store: open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(samplesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("store: init bucket: %w", err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+// sampleKey orders samples by PID then timestamp so a bucket scan over a
+// PID's prefix returns them in chronological order.
+func sampleKey(pid int32, ts time.Time) []byte {
+	key := make([]byte, 12)
+	binary.BigEndian.PutUint32(key[0:4], uint32(pid))
+	binary.BigEndian.PutUint64(key[4:12], uint64(ts.Unix()))
+	return key
+}
+
+func (b *boltStore) WriteSample(stats ResourceStats) error {
+	value, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(samplesBucket).Put(sampleKey(stats.PID, stats.Timestamp), value)
+	})
+}
+
+func (b *boltStore) Query(pid int32, from, to time.Time, step time.Duration) ([]ResourceStats, error) {
+	var out []ResourceStats
+	bucketSeconds := int64(step.Seconds())
+	if bucketSeconds <= 0 {
+		bucketSeconds = 1
+	}
+	lastBucket := int64(-1)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(samplesBucket).Cursor()
+		prefix := make([]byte, 4)
+		binary.BigEndian.PutUint32(prefix, uint32(pid))
+		for k, v := c.Seek(sampleKey(pid, from)); k != nil && len(k) >= 4 && string(k[0:4]) == string(prefix); k, v = c.Next() {
+			ts := int64(binary.BigEndian.Uint64(k[4:12]))
+			if ts > to.Unix() {
+				break
+			}
+			bucket := ts / bucketSeconds
+			if bucket == lastBucket {
+				continue
+			}
+			lastBucket = bucket
+			var stats ResourceStats
+			if err := json.Unmarshal(v, &stats); err != nil {
+				return err
+			}
+			out = append(out, stats)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (b *boltStore) Close() error { return b.db.Close() }
+
+// prune deletes every sample older than hourRetention, the longest tier
+// sqliteStore keeps anything around for.
+func (b *boltStore) prune() error {
+	cutoff := uint64(time.Now().Add(-hourRetention).Unix())
+	return b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(samplesBucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if len(k) < 12 {
+				continue
+			}
+			if binary.BigEndian.Uint64(k[4:12]) >= cutoff {
+				continue
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// startCompactor runs prune on a fixed interval until the process exits,
+// mirroring sqliteStore's compactor cadence.
+func (b *boltStore) startCompactor() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := b.prune(); err != nil {
+				log.Printf("Store pruning failed: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestBoltStore(t *testing.T) *boltStore {
+	t.Helper()
+	s, err := newBoltStore(filepath.Join(t.TempDir(), "monitor.bolt"))
+	if err != nil {
+		t.Fatalf("newBoltStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestBoltStorePruneCutoff(t *testing.T) {
+	s := newTestBoltStore(t)
+	now := time.Now()
+
+	expired := ResourceStats{PID: 1, ProcessName: "proc", Timestamp: now.Add(-hourRetention - time.Hour)}
+	kept := ResourceStats{PID: 1, ProcessName: "proc", Timestamp: now.Add(-hourRetention + time.Hour)}
+	if err := s.WriteSample(expired); err != nil {
+		t.Fatalf("WriteSample(expired): %v", err)
+	}
+	if err := s.WriteSample(kept); err != nil {
+		t.Fatalf("WriteSample(kept): %v", err)
+	}
+
+	if err := s.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	series, err := s.Query(1, now.Add(-2*hourRetention), now, time.Second)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("got %d samples after prune, want 1", len(series))
+	}
+	if series[0].Timestamp.Unix() != kept.Timestamp.Unix() {
+		t.Errorf("surviving sample timestamp = %v, want the kept sample (%v)", series[0].Timestamp, kept.Timestamp)
+	}
+}
+
+func TestBoltStorePruneKeepsBoundarySample(t *testing.T) {
+	s := newTestBoltStore(t)
+	now := time.Now()
+
+	atCutoff := ResourceStats{PID: 1, ProcessName: "proc", Timestamp: now.Add(-hourRetention)}
+	if err := s.WriteSample(atCutoff); err != nil {
+		t.Fatalf("WriteSample: %v", err)
+	}
+
+	if err := s.prune(); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	series, err := s.Query(1, now.Add(-2*hourRetention), now, time.Second)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(series) != 1 {
+		t.Errorf("got %d samples, want 1 (a sample exactly at the cutoff should survive, prune only deletes strictly older ones)", len(series))
+	}
+}
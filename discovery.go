@@ -0,0 +1,153 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var (
+	monitoredPIDs = make(map[int32]bool)
+	monitoredMu   sync.Mutex
+)
+
+// findTermuxProcesses is the fallback discovery used when no PID,
+// PID list or regex is configured.
+func findTermuxProcesses() []int32 {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	var pids []int32
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		if name == "termux" {
+			pids = append(pids, p.Pid)
+		}
+	}
+	return pids
+}
+
+// findProcessesByRegex matches pattern against each running process's
+// comm and full command line, so a regex like `^node` or `myapp` works
+// regardless of which one the target process exposes.
+func findProcessesByRegex(pattern *regexp.Regexp) []int32 {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	var pids []int32
+	for _, p := range procs {
+		if name, err := p.Name(); err == nil && pattern.MatchString(name) {
+			pids = append(pids, p.Pid)
+			continue
+		}
+		if cmdline, err := p.Cmdline(); err == nil && pattern.MatchString(cmdline) {
+			pids = append(pids, p.Pid)
+		}
+	}
+	return pids
+}
+
+// collectChildrenRecursive walks the process tree rooted at pid and
+// returns every descendant, so a shell plus everything it spawns is
+// captured. seen is shared across the whole discovery pass to avoid
+// visiting the same PID twice if the tree has converging paths.
+func collectChildrenRecursive(pid int32, seen map[int32]bool) []int32 {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil
+	}
+	children, err := proc.Children()
+	if err != nil {
+		return nil
+	}
+	var pids []int32
+	for _, child := range children {
+		if seen[child.Pid] {
+			continue
+		}
+		seen[child.Pid] = true
+		pids = append(pids, child.Pid)
+		pids = append(pids, collectChildrenRecursive(child.Pid, seen)...)
+	}
+	return pids
+}
+
+// parsePIDList parses a comma-separated MONITOR_PIDS value.
+func parsePIDList(raw string) []int32 {
+	var pids []int32
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			log.Printf("Ignoring invalid PID in MONITOR_PIDS: %q", part)
+			continue
+		}
+		pids = append(pids, int32(n))
+	}
+	return pids
+}
+
+// isMonitoredPID reports whether pid is one this instance is actively
+// sampling. Handlers that shell out or read another process's /proc
+// entry (e.g. profileHandler) must check this before acting on a
+// caller-supplied PID, since these HTTP endpoints have no auth of
+// their own.
+func isMonitoredPID(pid int32) bool {
+	monitoredMu.Lock()
+	defer monitoredMu.Unlock()
+	return monitoredPIDs[pid]
+}
+
+// startMonitoring launches monitorProcess for pid if it isn't already
+// being watched, so repeated discovery passes are idempotent.
+func startMonitoring(pid int32) {
+	monitoredMu.Lock()
+	defer monitoredMu.Unlock()
+	if monitoredPIDs[pid] {
+		return
+	}
+	monitoredPIDs[pid] = true
+	go monitorProcess(pid)
+}
+
+// processSupervisor drives discovery for the configured root PIDs. When
+// followChildren is set it keeps re-walking the process tree on an
+// interval so new children spawned after startup get picked up too.
+func processSupervisor(rootPIDs []int32, followChildren bool) {
+	discover := func() {
+		seen := make(map[int32]bool)
+		for _, pid := range rootPIDs {
+			seen[pid] = true
+			startMonitoring(pid)
+			if followChildren {
+				for _, child := range collectChildrenRecursive(pid, seen) {
+					startMonitoring(child)
+				}
+			}
+		}
+	}
+
+	discover()
+	if !followChildren {
+		return
+	}
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		discover()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// parseRangeParams reads the from/to/step query params shared by the
+// range-query path of /stats and /export. from/to are RFC3339
+// timestamps; step is a Go duration string (e.g. "1m"). Missing from/to
+// default to a 1-hour trailing window, and step defaults to 5s.
+func parseRangeParams(q url.Values) (from, to time.Time, step time.Duration, err error) {
+	to = time.Now()
+	from = to.Add(-1 * time.Hour)
+	step = 5 * time.Second
+
+	if raw := q.Get("to"); raw != "" {
+		to, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if raw := q.Get("from"); raw != "" {
+		from, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if raw := q.Get("step"); raw != "" {
+		step, err = time.ParseDuration(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("invalid step: %w", err)
+		}
+	}
+	return from, to, step, nil
+}
+
+// exportHandler bulk-extracts a PID's stored history as
+// ?format=csv|json|prometheus, honoring the same from/to/step params as
+// the /stats range query.
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	if dataStore == nil {
+		http.Error(w, "no store configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	pidParam := r.URL.Query().Get("pid")
+	if pidParam == "" {
+		http.Error(w, "pid is required", http.StatusBadRequest)
+		return
+	}
+	pid, err := strconv.ParseInt(pidParam, 10, 32)
+	if err != nil {
+		http.Error(w, "invalid pid", http.StatusBadRequest)
+		return
+	}
+
+	from, to, step, err := parseRangeParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := dataStore.Query(int32(pid), from, to, step)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		// network_rx/tx are host-wide totals (see getNetworkStats in
+		// main.go), not per-process, so they're deliberately left out
+		// here rather than printed once per PID as if each process
+		// produced that traffic independently.
+		w.Header().Set("Content-Type", "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"timestamp", "pid", "process_name", "cpu_percent", "memory_mb"})
+		for _, s := range series {
+			writer.Write([]string{
+				s.Timestamp.Format(time.RFC3339),
+				strconv.Itoa(int(s.PID)),
+				s.ProcessName,
+				strconv.FormatFloat(s.CPUPercent, 'f', 2, 64),
+				strconv.FormatFloat(s.MemoryMB, 'f', 2, 64),
+			})
+		}
+		writer.Flush()
+	case "prometheus":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, s := range series {
+			ts := s.Timestamp.UnixMilli()
+			fmt.Fprintf(w, "termux_process_cpu_percent{pid=\"%d\",process_name=\"%s\"} %f %d\n", s.PID, s.ProcessName, s.CPUPercent, ts)
+			fmt.Fprintf(w, "termux_process_memory_bytes{pid=\"%d\",process_name=\"%s\",type=\"rss\"} %f %d\n", s.PID, s.ProcessName, s.MemoryMB*1024*1024, ts)
+		}
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(series)
+	}
+}
@@ -2,168 +2,172 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-)
 
-// ResourceStats 资源统计数据
-type ResourceStats struct {
-	Timestamp   time.Time `json:"timestamp"`
-	PID         int       `json:"pid"`
-	ProcessName string    `json:"process_name"`
-	CPUPercent  float64   `json:"cpu_percent"`
-	MemoryMB    float64   `json:"memory_mb"`
-	NetworkRx   uint64    `json:"network_rx_bytes"`
-	NetworkTx   uint64    `json:"network_tx_bytes"`
-}
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shirou/gopsutil/v3/process"
+)
 
 var (
-	statsHistory []ResourceStats
+	statsHistory = make(map[int32][]ResourceStats)
 	statsMutex   sync.RWMutex
 	maxHistory   = 1000
+	collector    = NewCollector()
+	dataStore    Store
 )
 
-func getCPUUsage(pid int) (float64, error) {
-	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
-	if err != nil {
-		return 0, err
-	}
-	fields := strings.Fields(string(data))
-	if len(fields) < 17 {
-		return 0, fmt.Errorf("invalid stat format")
-	}
-	utime, _ := strconv.ParseUint(fields[13], 10, 64)
-	stime, _ := strconv.ParseUint(fields[14], 10, 64)
-	totalTime := utime + stime
-	uptimeData, err := os.ReadFile("/proc/uptime")
-	if err != nil {
-		return 0, err
-	}
-	uptimeFields := strings.Fields(string(uptimeData))
-	uptime, _ := strconv.ParseFloat(uptimeFields[0], 64)
-	hertz := 100.0
-	seconds := uptime - (float64(totalTime) / hertz)
-	if seconds > 0 {
-		return (float64(totalTime) / hertz / seconds) * 100, nil
-	}
-	return 0, nil
-}
+// monitorProcess samples pid on a fixed interval until it exits, then
+// stops cleanly. The last samples collected are left in statsHistory for
+// post-mortem inspection rather than being cleared out.
+func monitorProcess(pid int32) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer func() {
+		ticker.Stop()
+		monitoredMu.Lock()
+		delete(monitoredPIDs, pid)
+		monitoredMu.Unlock()
+		log.Printf("Stopped monitoring PID %d (process exited)", pid)
+	}()
 
-func getMemoryUsage(pid int) (float64, error) {
-	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
-	if err != nil {
-		return 0, err
-	}
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "VmRSS:") {
-			fields := strings.Fields(line)
-			if len(fields) >= 2 {
-				kb, _ := strconv.ParseFloat(fields[1], 64)
-				return kb / 1024, nil
-			}
+	for range ticker.C {
+		if exists, err := process.PidExists(pid); err != nil || !exists {
+			return
 		}
-	}
-	return 0, nil
-}
-
-func getNetworkStats(pid int) (rx, tx uint64, err error) {
-	data, err := os.ReadFile("/proc/net/dev")
-	if err != nil {
-		return 0, 0, err
-	}
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ":") {
-			fields := strings.Fields(line)
-			if len(fields) >= 10 {
-				rxBytes, _ := strconv.ParseUint(fields[1], 10, 64)
-				txBytes, _ := strconv.ParseUint(fields[9], 10, 64)
-				rx += rxBytes
-				tx += txBytes
+		stats, err := collector.Collect(pid)
+		if err != nil {
+			log.Printf("Failed to collect stats for PID %d: %v", pid, err)
+			return
+		}
+		statsMutex.Lock()
+		history := append(statsHistory[pid], stats)
+		if len(history) > maxHistory {
+			history = history[len(history)-maxHistory:]
+		}
+		statsHistory[pid] = history
+		statsMutex.Unlock()
+		observeSampleMetrics(stats)
+		if dataStore != nil {
+			if err := dataStore.WriteSample(stats); err != nil {
+				log.Printf("Failed to persist sample for PID %d: %v", pid, err)
 			}
 		}
+		log.Printf("PID %d [%s]: CPU=%.2f%%, MEM=%.2f MB", pid, stats.ProcessName, stats.CPUPercent, stats.MemoryMB)
 	}
-	return rx, tx, nil
 }
 
-func getProcessName(pid int) string {
-	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
-	if err != nil {
-		return "unknown"
-	}
-	return strings.TrimSpace(string(data))
-}
+// statsHandler serves the collected per-PID history, optionally filtered
+// by ?pid= (exact PID) or ?name= (substring match against the most
+// recent sample's process name). When ?pid= is combined with ?from=/?to=
+// (RFC3339) and a backing Store is configured, it instead answers from
+// the store's downsampled range rather than the in-memory window.
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-func monitorProcess(pid int) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-	for range ticker.C {
-		cpu, err := getCPUUsage(pid)
-		if err != nil {
-			log.Printf("Failed to get CPU for PID %d: %v", pid, err)
-			continue
-		}
-		mem, err := getMemoryUsage(pid)
-		if err != nil {
-			log.Printf("Failed to get memory for PID %d: %v", pid, err)
-			continue
-		}
-		rx, tx, err := getNetworkStats(pid)
+	if pidParam := r.URL.Query().Get("pid"); pidParam != "" {
+		pid, err := strconv.ParseInt(pidParam, 10, 32)
 		if err != nil {
-			log.Printf("Failed to get network stats: %v", err)
+			http.Error(w, "invalid pid", http.StatusBadRequest)
+			return
 		}
-		stats := ResourceStats{
-			Timestamp:   time.Now(),
-			PID:         pid,
-			ProcessName: getProcessName(pid),
-			CPUPercent:  cpu,
-			MemoryMB:    mem,
-			NetworkRx:   rx,
-			NetworkTx:   tx,
-		}
-		statsMutex.Lock()
-		statsHistory = append(statsHistory, stats)
-		if len(statsHistory) > maxHistory {
-			statsHistory = statsHistory[1:]
+
+		fromParam, toParam := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		if dataStore != nil && (fromParam != "" || toParam != "") {
+			from, to, step, err := parseRangeParams(r.URL.Query())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			series, err := dataStore.Query(int32(pid), from, to, step)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(series)
+			return
 		}
-		statsMutex.Unlock()
-		log.Printf("PID %d [%s]: CPU=%.2f%%, MEM=%.2f MB", pid, stats.ProcessName, cpu, mem)
+
+		statsMutex.RLock()
+		defer statsMutex.RUnlock()
+		json.NewEncoder(w).Encode(statsHistory[int32(pid)])
+		return
 	}
-}
 
-func statsHandler(w http.ResponseWriter, r *http.Request) {
 	statsMutex.RLock()
 	defer statsMutex.RUnlock()
-	w.Header().Set("Content-Type", "application/json")
+
+	if name := r.URL.Query().Get("name"); name != "" {
+		filtered := make(map[int32][]ResourceStats)
+		for pid, history := range statsHistory {
+			if len(history) == 0 {
+				continue
+			}
+			if strings.Contains(history[len(history)-1].ProcessName, name) {
+				filtered[pid] = history
+			}
+		}
+		json.NewEncoder(w).Encode(filtered)
+		return
+	}
+
 	json.NewEncoder(w).Encode(statsHistory)
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "OK")
+	w.Write([]byte("OK"))
 }
 
-func findTermuxProcesses() []int {
-	cmd := exec.Command("pgrep", "-x", "termux")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-	var pids []int
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if pid, err := strconv.Atoi(line); err == nil {
+// resolveTargetPIDs builds the initial root PID set from MONITOR_PID,
+// MONITOR_PIDS and MONITOR_PROCESS_REGEX (any combination may be set),
+// falling back to findTermuxProcesses when none are configured.
+func resolveTargetPIDs() []int32 {
+	seen := make(map[int32]bool)
+	var pids []int32
+	add := func(pid int32) {
+		if !seen[pid] {
+			seen[pid] = true
 			pids = append(pids, pid)
 		}
 	}
+
+	if pidStr := os.Getenv("MONITOR_PID"); pidStr != "" {
+		pid, err := strconv.ParseInt(strings.TrimSpace(pidStr), 10, 32)
+		if err != nil {
+			log.Fatalf("Invalid PID: %s", pidStr)
+		}
+		add(int32(pid))
+	}
+
+	if pidsStr := os.Getenv("MONITOR_PIDS"); pidsStr != "" {
+		for _, pid := range parsePIDList(pidsStr) {
+			add(pid)
+		}
+	}
+
+	if pattern := os.Getenv("MONITOR_PROCESS_REGEX"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Fatalf("Invalid MONITOR_PROCESS_REGEX: %v", err)
+		}
+		for _, pid := range findProcessesByRegex(re) {
+			add(pid)
+		}
+	}
+
+	if len(pids) == 0 {
+		log.Println("No MONITOR_PID/MONITOR_PIDS/MONITOR_PROCESS_REGEX specified, attempting to find Termux processes...")
+		for _, pid := range findTermuxProcesses() {
+			add(pid)
+		}
+	}
+
 	return pids
 }
 
@@ -172,26 +176,46 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
-	pidStr := os.Getenv("MONITOR_PID")
-	if pidStr == "" {
-		log.Println("No MONITOR_PID specified, attempting to find Termux processes...")
-		pids := findTermuxProcesses()
-		if len(pids) > 0 {
-			log.Printf("Found %d Termux processes: %v", len(pids), pids)
-			go monitorProcess(pids[0])
-		} else {
-			log.Fatal("No processes found. Please set MONITOR_PID environment variable.")
-		}
-	} else {
-		pid, err := strconv.Atoi(pidStr)
+
+	store, err := NewStore()
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+	dataStore = store
+	defer dataStore.Close()
+	if compactable, ok := store.(interface{ startCompactor() }); ok {
+		compactable.startCompactor()
+	}
+
+	pids := resolveTargetPIDs()
+	if len(pids) == 0 {
+		log.Fatal("No processes found. Please set MONITOR_PID, MONITOR_PIDS or MONITOR_PROCESS_REGEX.")
+	}
+	log.Printf("Monitoring %d process(es): %v", len(pids), pids)
+
+	followChildren := os.Getenv("MONITOR_FOLLOW_CHILDREN") == "true"
+	go processSupervisor(pids, followChildren)
+	go systemMonitor()
+	registerMetrics()
+
+	notifiers = configureNotifiers()
+	if rulesPath := os.Getenv("MONITOR_ALERT_RULES_FILE"); rulesPath != "" {
+		rules, err := loadAlertRules(rulesPath)
 		if err != nil {
-			log.Fatalf("Invalid PID: %s", pidStr)
+			log.Fatalf("Failed to load alert rules: %v", err)
 		}
-		log.Printf("Starting monitor for PID: %d", pid)
-		go monitorProcess(pid)
+		setAlertRules(rules)
 	}
+	go alertEvaluator()
+
 	http.HandleFunc("/stats", statsHandler)
+	http.HandleFunc("/system", systemHandler)
 	http.HandleFunc("/health", healthHandler)
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/rules", rulesHandler)
+	http.HandleFunc("/alerts", alertsHandler)
+	http.HandleFunc("/export", exportHandler)
+	http.HandleFunc("/profile/", profileHandler)
 	log.Printf("Monitor API running on :%s", port)
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
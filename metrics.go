@@ -0,0 +1,150 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric descriptors for the process- and system-level series. Every
+// process series carries {pid, process_name} labels so a dashboard can
+// group or split by either.
+var (
+	cpuPercentDesc  = prometheus.NewDesc("termux_process_cpu_percent", "Per-process CPU usage percent.", []string{"pid", "process_name"}, nil)
+	memoryBytesDesc = prometheus.NewDesc("termux_process_memory_bytes", "Per-process memory usage in bytes by type (rss|vms|shared).", []string{"pid", "process_name", "type"}, nil)
+	openFDsDesc     = prometheus.NewDesc("termux_process_open_fds", "Number of open file descriptors.", []string{"pid", "process_name"}, nil)
+	threadsDesc     = prometheus.NewDesc("termux_process_threads", "Number of threads.", []string{"pid", "process_name"}, nil)
+	ioReadDesc      = prometheus.NewDesc("termux_process_io_read_bytes", "Cumulative bytes read by the process.", []string{"pid", "process_name"}, nil)
+	ioWriteDesc     = prometheus.NewDesc("termux_process_io_write_bytes", "Cumulative bytes written by the process.", []string{"pid", "process_name"}, nil)
+
+	systemLoadDesc  = prometheus.NewDesc("termux_system_load", "Host load average.", []string{"period"}, nil)
+	systemMemDesc   = prometheus.NewDesc("termux_system_memory_bytes", "Host memory usage in bytes by type.", []string{"type"}, nil)
+	systemDiskDesc  = prometheus.NewDesc("termux_system_disk_used_bytes", "Disk bytes used per mountpoint.", []string{"mountpoint"}, nil)
+	systemNetRxDesc = prometheus.NewDesc("termux_system_network_rx_bytes", "Cumulative network bytes received, per interface.", []string{"interface"}, nil)
+	systemNetTxDesc = prometheus.NewDesc("termux_system_network_tx_bytes", "Cumulative network bytes sent, per interface.", []string{"interface"}, nil)
+)
+
+// cpuPercentHistogram and memoryMBHistogram track the distribution of
+// sampled per-process CPU/memory usage, complementing the latest-value
+// gauges above with shape (p50/p90/etc. via histogram_quantile) that a
+// single gauge can't express. Bucket boundaries are configurable per
+// deployment since "busy" looks very different for a background daemon
+// than for a Termux build job.
+var (
+	cpuPercentHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "termux_process_cpu_percent_distribution",
+		Help:    "Distribution of sampled per-process CPU usage percent.",
+		Buckets: parseHistogramBuckets("MONITOR_METRICS_CPU_BUCKETS", []float64{5, 10, 25, 50, 75, 90, 100}),
+	}, []string{"pid", "process_name"})
+
+	memoryMBHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "termux_process_memory_mb_distribution",
+		Help:    "Distribution of sampled per-process memory usage in MB.",
+		Buckets: parseHistogramBuckets("MONITOR_METRICS_MEMORY_BUCKETS", []float64{50, 100, 250, 500, 1000, 2000}),
+	}, []string{"pid", "process_name"})
+)
+
+// parseHistogramBuckets reads a comma-separated list of float64 bucket
+// boundaries from envVar, falling back to def when the var is unset or
+// every value in it fails to parse.
+func parseHistogramBuckets(envVar string, def []float64) []float64 {
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return def
+	}
+	var buckets []float64
+	for _, part := range strings.Split(raw, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			log.Printf("Ignoring invalid bucket boundary in %s: %q", envVar, part)
+			continue
+		}
+		buckets = append(buckets, v)
+	}
+	if len(buckets) == 0 {
+		return def
+	}
+	return buckets
+}
+
+// processMetricsCollector is a prometheus.Collector that reads straight
+// from the latest statsHistory/systemStats snapshot on every scrape,
+// instead of mirroring state into prometheus primitives on each tick.
+type processMetricsCollector struct{}
+
+func (processMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cpuPercentDesc
+	ch <- memoryBytesDesc
+	ch <- openFDsDesc
+	ch <- threadsDesc
+	ch <- ioReadDesc
+	ch <- ioWriteDesc
+	ch <- systemLoadDesc
+	ch <- systemMemDesc
+	ch <- systemDiskDesc
+	ch <- systemNetRxDesc
+	ch <- systemNetTxDesc
+}
+
+func (processMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	statsMutex.RLock()
+	latest := make(map[int32]ResourceStats, len(statsHistory))
+	for pid, history := range statsHistory {
+		if len(history) == 0 {
+			continue
+		}
+		latest[pid] = history[len(history)-1]
+	}
+	statsMutex.RUnlock()
+
+	for pid, s := range latest {
+		pidLabel := strconv.Itoa(int(pid))
+		ch <- prometheus.MustNewConstMetric(cpuPercentDesc, prometheus.GaugeValue, s.CPUPercent, pidLabel, s.ProcessName)
+		ch <- prometheus.MustNewConstMetric(memoryBytesDesc, prometheus.GaugeValue, float64(s.RSSBytes), pidLabel, s.ProcessName, "rss")
+		ch <- prometheus.MustNewConstMetric(memoryBytesDesc, prometheus.GaugeValue, float64(s.VMSBytes), pidLabel, s.ProcessName, "vms")
+		ch <- prometheus.MustNewConstMetric(memoryBytesDesc, prometheus.GaugeValue, float64(s.SharedBytes), pidLabel, s.ProcessName, "shared")
+		ch <- prometheus.MustNewConstMetric(openFDsDesc, prometheus.GaugeValue, float64(s.NumFDs), pidLabel, s.ProcessName)
+		ch <- prometheus.MustNewConstMetric(threadsDesc, prometheus.GaugeValue, float64(s.NumThreads), pidLabel, s.ProcessName)
+		ch <- prometheus.MustNewConstMetric(ioReadDesc, prometheus.CounterValue, float64(s.IOReadBytes), pidLabel, s.ProcessName)
+		ch <- prometheus.MustNewConstMetric(ioWriteDesc, prometheus.CounterValue, float64(s.IOWriteBytes), pidLabel, s.ProcessName)
+	}
+
+	systemStatsMutex.RLock()
+	sys := systemStats
+	systemStatsMutex.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(systemLoadDesc, prometheus.GaugeValue, sys.Load1, "1m")
+	ch <- prometheus.MustNewConstMetric(systemLoadDesc, prometheus.GaugeValue, sys.Load5, "5m")
+	ch <- prometheus.MustNewConstMetric(systemLoadDesc, prometheus.GaugeValue, sys.Load15, "15m")
+	ch <- prometheus.MustNewConstMetric(systemMemDesc, prometheus.GaugeValue, float64(sys.MemTotal), "total")
+	ch <- prometheus.MustNewConstMetric(systemMemDesc, prometheus.GaugeValue, float64(sys.MemUsed), "used")
+	ch <- prometheus.MustNewConstMetric(systemMemDesc, prometheus.GaugeValue, float64(sys.MemFree), "free")
+	ch <- prometheus.MustNewConstMetric(systemMemDesc, prometheus.GaugeValue, float64(sys.MemCached), "cached")
+	for _, d := range sys.Disks {
+		ch <- prometheus.MustNewConstMetric(systemDiskDesc, prometheus.GaugeValue, float64(d.UsedBytes), d.Mountpoint)
+	}
+	for _, nic := range sys.Interfaces {
+		ch <- prometheus.MustNewConstMetric(systemNetRxDesc, prometheus.CounterValue, float64(nic.RxBytes), nic.Name)
+		ch <- prometheus.MustNewConstMetric(systemNetTxDesc, prometheus.CounterValue, float64(nic.TxBytes), nic.Name)
+	}
+}
+
+// registerMetrics wires the Collector into the default prometheus
+// registry; call once at startup before serving /metrics.
+func registerMetrics() {
+	prometheus.MustRegister(processMetricsCollector{})
+	prometheus.MustRegister(cpuPercentHistogram, memoryMBHistogram)
+}
+
+// observeSampleMetrics feeds a freshly collected sample into the
+// distribution histograms. Unlike processMetricsCollector, which reads
+// the latest snapshot lazily on scrape, a histogram needs every sample
+// observed as it's collected or its buckets never fill in.
+func observeSampleMetrics(stats ResourceStats) {
+	pidLabel := strconv.Itoa(int(stats.PID))
+	cpuPercentHistogram.WithLabelValues(pidLabel, stats.ProcessName).Observe(stats.CPUPercent)
+	memoryMBHistogram.WithLabelValues(pidLabel, stats.ProcessName).Observe(stats.MemoryMB)
+}
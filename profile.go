@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+	gopsutilnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// profileHandler serves /profile/{pid}/{type}, capturing an on-demand
+// profile of the *monitored* process (never the monitor itself) in a
+// format `go tool pprof` can read directly.
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 {
+		http.Error(w, "expected /profile/{pid}/{type}", http.StatusBadRequest)
+		return
+	}
+	pid, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		http.Error(w, "invalid pid", http.StatusBadRequest)
+		return
+	}
+	if !isMonitoredPID(int32(pid)) {
+		http.Error(w, "pid is not a monitored process", http.StatusForbidden)
+		return
+	}
+
+	const maxProfileSeconds = 60
+
+	var data []byte
+	switch profileType := parts[2]; profileType {
+	case "cpu":
+		seconds := 10
+		if raw := r.URL.Query().Get("seconds"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxProfileSeconds {
+				http.Error(w, fmt.Sprintf("seconds must be an integer between 1 and %d", maxProfileSeconds), http.StatusBadRequest)
+				return
+			}
+			seconds = n
+		}
+		data, err = captureCPUProfile(int32(pid), seconds)
+	case "heap":
+		data, err = captureHeapProfile(int32(pid))
+	case "goroutine":
+		data, err = captureGoroutineProfile(int32(pid))
+	default:
+		http.Error(w, fmt.Sprintf("unsupported profile type %q", profileType), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+// captureCPUProfile wraps `perf record`/`perf script` to sample the
+// target process for the given duration, then folds the stack samples
+// into a pprof profile.
+func captureCPUProfile(pid int32, seconds int) ([]byte, error) {
+	tmpFile, err := os.CreateTemp("", "termux-monitor-perf-*.data")
+	if err != nil {
+		return nil, fmt.Errorf("profile: create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	record := exec.Command("perf", "record", "-g", "-p", strconv.Itoa(int(pid)), "-o", tmpPath, "--", "sleep", strconv.Itoa(seconds))
+	if out, err := record.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("profile: perf record: %w: %s", err, out)
+	}
+
+	script := exec.Command("perf", "script", "-i", tmpPath)
+	out, err := script.Output()
+	if err != nil {
+		return nil, fmt.Errorf("profile: perf script: %w", err)
+	}
+
+	return perfScriptToPprof(out, seconds)
+}
+
+// perfScriptToPprof folds `perf script` text output into a pprof
+// profile.Profile: one sample per stack, weighted by occurrence count.
+func perfScriptToPprof(perfScript []byte, durationSeconds int) ([]byte, error) {
+	functions := map[string]*profile.Function{}
+	locations := map[string]*profile.Location{}
+	sampleCounts := map[string]int64{}
+	sampleStacks := map[string][]*profile.Location{}
+
+	var nextID uint64 = 1
+	functionFor := func(name string) *profile.Function {
+		if fn, ok := functions[name]; ok {
+			return fn
+		}
+		fn := &profile.Function{ID: nextID, Name: name, SystemName: name}
+		nextID++
+		functions[name] = fn
+		return fn
+	}
+	locationFor := func(name string) *profile.Location {
+		if loc, ok := locations[name]; ok {
+			return loc
+		}
+		loc := &profile.Location{
+			ID:   nextID,
+			Line: []profile.Line{{Function: functionFor(name)}},
+		}
+		nextID++
+		locations[name] = loc
+		return loc
+	}
+
+	var stack []*profile.Location
+	var stackKey strings.Builder
+	flush := func() {
+		if len(stack) == 0 {
+			return
+		}
+		key := stackKey.String()
+		sampleCounts[key]++
+		if _, ok := sampleStacks[key]; !ok {
+			sampleStacks[key] = append([]*profile.Location(nil), stack...)
+		}
+		stack = nil
+		stackKey.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(perfScript))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// header line for a new sample ("<comm> <pid> <time>: ...")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		symbol := fields[1]
+		stack = append(stack, locationFor(symbol))
+		stackKey.WriteString(symbol)
+		stackKey.WriteByte('/')
+	}
+	flush()
+
+	p := &profile.Profile{
+		SampleType:     []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		DurationNanos:  int64(durationSeconds) * int64(time.Second),
+		TimeNanos:      time.Now().UnixNano(),
+		PeriodType:     &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:         1,
+	}
+	for key, locs := range sampleStacks {
+		p.Sample = append(p.Sample, &profile.Sample{
+			Location: locs,
+			Value:    []int64{sampleCounts[key], sampleCounts[key]},
+		})
+	}
+	for _, fn := range functions {
+		p.Function = append(p.Function, fn)
+	}
+	for _, loc := range locations {
+		p.Location = append(p.Location, loc)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, fmt.Errorf("profile: encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// captureHeapProfile builds a synthetic heap profile from
+// /proc/<pid>/smaps_rollup, one sample per memory category (Rss, Pss,
+// Shared_Clean, ...) so it can still be inspected with `go tool pprof`
+// even though it isn't a real Go heap profile.
+func captureHeapProfile(pid int32) ([]byte, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/smaps_rollup", pid))
+	if err != nil {
+		return nil, fmt.Errorf("profile: read smaps_rollup for %d: %w", pid, err)
+	}
+
+	valueType := &profile.ValueType{Type: "bytes", Unit: "bytes"}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{valueType},
+		PeriodType: valueType,
+		Period:     1,
+		TimeNanos:  time.Now().UnixNano(),
+	}
+
+	var nextID uint64 = 1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		category := strings.TrimSuffix(fields[0], ":")
+		if category == "" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		fn := &profile.Function{ID: nextID, Name: category, SystemName: category}
+		nextID++
+		loc := &profile.Location{ID: nextID, Line: []profile.Line{{Function: fn}}}
+		nextID++
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		p.Sample = append(p.Sample, &profile.Sample{
+			Location: []*profile.Location{loc},
+			Value:    []int64{kb * 1024},
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, fmt.Errorf("profile: encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// captureGoroutineProfile only works if the target is itself a Go
+// binary with net/http/pprof enabled: it probes the process's listening
+// ports for a /debug/pprof/ handler and proxies the goroutine profile.
+func captureGoroutineProfile(pid int32) ([]byte, error) {
+	conns, err := gopsutilnet.ConnectionsPid("tcp", pid)
+	if err != nil {
+		return nil, fmt.Errorf("profile: list connections for %d: %w", pid, err)
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	for _, conn := range conns {
+		if conn.Status != "LISTEN" || conn.Laddr.Port == 0 {
+			continue
+		}
+		url := fmt.Sprintf("http://127.0.0.1:%d/debug/pprof/goroutine?debug=0", conn.Laddr.Port)
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("profile: no /debug/pprof/ endpoint found for PID %d (not a Go binary with pprof enabled?)", pid)
+}
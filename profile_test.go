@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func parseProfile(t *testing.T, data []byte) *profile.Profile {
+	t.Helper()
+	p, err := profile.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("profile.Parse: %v", err)
+	}
+	return p
+}
+
+func sampleStackNames(p *profile.Profile, s *profile.Sample) []string {
+	var names []string
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			names = append(names, line.Function.Name)
+		}
+	}
+	return names
+}
+
+func TestPerfScriptToPprofEmptyInput(t *testing.T) {
+	data, err := perfScriptToPprof([]byte(""), 10)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if len(p.Sample) != 0 {
+		t.Errorf("got %d samples, want 0", len(p.Sample))
+	}
+}
+
+func TestPerfScriptToPprofSingleStack(t *testing.T) {
+	script := "myapp 1234 100.0: cpu-clock:\n" +
+		"\t    1000 foo+0x10 (/bin/myapp)\n" +
+		"\t    2000 bar+0x20 (/bin/myapp)\n" +
+		"\n"
+	data, err := perfScriptToPprof([]byte(script), 10)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if len(p.Sample) != 1 {
+		t.Fatalf("got %d samples, want 1", len(p.Sample))
+	}
+	if got := p.Sample[0].Value; len(got) != 2 || got[0] != 1 || got[1] != 1 {
+		t.Errorf("sample value = %v, want [1 1]", got)
+	}
+	if names := sampleStackNames(p, p.Sample[0]); len(names) != 2 || names[0] != "foo+0x10" || names[1] != "bar+0x20" {
+		t.Errorf("stack = %v, want [foo+0x10 bar+0x20]", names)
+	}
+}
+
+func TestPerfScriptToPprofMergesRepeatedStacks(t *testing.T) {
+	script := "myapp 1234 100.0: cpu-clock:\n" +
+		"\t    1000 foo+0x10 (/bin/myapp)\n" +
+		"\n" +
+		"myapp 1234 100.1: cpu-clock:\n" +
+		"\t    1000 foo+0x10 (/bin/myapp)\n" +
+		"\n"
+	data, err := perfScriptToPprof([]byte(script), 10)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if len(p.Sample) != 1 {
+		t.Fatalf("got %d samples, want 1 (identical stacks should be folded together)", len(p.Sample))
+	}
+	if got := p.Sample[0].Value; len(got) != 2 || got[0] != 2 {
+		t.Errorf("sample value = %v, want count 2", got)
+	}
+}
+
+func TestPerfScriptToPprofDistinctStacksKeptSeparate(t *testing.T) {
+	script := "myapp 1234 100.0: cpu-clock:\n" +
+		"\t    1000 foo+0x10 (/bin/myapp)\n" +
+		"\n" +
+		"myapp 1234 100.1: cpu-clock:\n" +
+		"\t    1000 baz+0x30 (/bin/myapp)\n" +
+		"\n"
+	data, err := perfScriptToPprof([]byte(script), 10)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if len(p.Sample) != 2 {
+		t.Fatalf("got %d samples, want 2 (distinct stacks should not be merged)", len(p.Sample))
+	}
+}
+
+func TestPerfScriptToPprofSkipsHeaderAndShortLines(t *testing.T) {
+	script := "myapp 1234 100.0: cpu-clock:\n" +
+		"\t\n" +
+		"\t    1000 foo+0x10 (/bin/myapp)\n" +
+		"\n"
+	data, err := perfScriptToPprof([]byte(script), 10)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if len(p.Sample) != 1 {
+		t.Fatalf("got %d samples, want 1", len(p.Sample))
+	}
+	if names := sampleStackNames(p, p.Sample[0]); len(names) != 1 || names[0] != "foo+0x10" {
+		t.Errorf("stack = %v, want [foo+0x10] (the blank tab-only line should be skipped, not treated as a frame)", names)
+	}
+}
+
+func TestPerfScriptToPprofSetsDuration(t *testing.T) {
+	data, err := perfScriptToPprof([]byte(""), 30)
+	if err != nil {
+		t.Fatalf("perfScriptToPprof: %v", err)
+	}
+	p := parseProfile(t, data)
+	if want := int64(30) * 1e9; p.DurationNanos != want {
+		t.Errorf("DurationNanos = %d, want %d", p.DurationNanos, want)
+	}
+}
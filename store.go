@@ -0,0 +1,237 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store is a pluggable persistence layer for resource samples, so
+// history survives a restart and can be queried over arbitrary ranges
+// instead of only the last maxHistory in-memory entries.
+type Store interface {
+	WriteSample(stats ResourceStats) error
+	Query(pid int32, from, to time.Time, step time.Duration) ([]ResourceStats, error)
+	Close() error
+}
+
+// Retention tiers the compactor enforces: raw samples are kept at full
+// resolution for an hour, then rolled up into 1-minute averages for a
+// day, then 1-hour averages for 30 days.
+const (
+	rawRetention    = 1 * time.Hour
+	minuteRetention = 24 * time.Hour
+	hourRetention   = 30 * 24 * time.Hour
+)
+
+// defaultStorePath mirrors Termux's own convention for persistent
+// service state under $PREFIX.
+func defaultStorePath() string {
+	prefix := os.Getenv("PREFIX")
+	if prefix == "" {
+		prefix = "/data/data/com.termux/files/usr"
+	}
+	return filepath.Join(prefix, "var", "lib", "termux-monitor", "monitor.db")
+}
+
+// NewStore builds the configured Store backend. MONITOR_STORE_BACKEND
+// selects "sqlite" (default) or "bolt"; MONITOR_STORE_PATH overrides the
+// on-disk path for either.
+func NewStore() (Store, error) {
+	backend := os.Getenv("MONITOR_STORE_BACKEND")
+	path := os.Getenv("MONITOR_STORE_PATH")
+
+	switch backend {
+	case "bolt":
+		if path == "" {
+			path = filepath.Join(filepath.Dir(defaultStorePath()), "monitor.bolt")
+		}
+		return newBoltStore(path)
+	default:
+		if path == "" {
+			path = defaultStorePath()
+		}
+		return newSQLiteStore(path)
+	}
+}
+
+// sqliteStore is the default Store backend: modernc.org/sqlite is pure
+// Go, so it works on Termux without a CGO toolchain.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(path string) (*sqliteStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("store: create %s: %w", dir, err)
+		}
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("store: open %s: %w", path, err)
+	}
+	s := &sqliteStore{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// sampleColumns lists every ResourceStats field persisted, shared by
+// all three tiers so rollups can SELECT/INSERT across them uniformly.
+// per_cpu_percent is stored as a JSON array: it's an instantaneous
+// per-core snapshot, not something a rollup can meaningfully average.
+const sampleColumns = `pid, process_name, ts, cpu_percent, per_cpu_percent, memory_mb,
+	rss_bytes, vms_bytes, shared_bytes, num_threads, num_fds,
+	io_read_bytes, io_write_bytes, ctx_switches_vol, ctx_switches_inv`
+
+func (s *sqliteStore) migrate() error {
+	tier := func(name string) string {
+		return fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+	pid INTEGER NOT NULL, process_name TEXT NOT NULL, ts INTEGER NOT NULL,
+	cpu_percent REAL, per_cpu_percent TEXT, memory_mb REAL,
+	rss_bytes INTEGER, vms_bytes INTEGER, shared_bytes INTEGER,
+	num_threads INTEGER, num_fds INTEGER,
+	io_read_bytes INTEGER, io_write_bytes INTEGER,
+	ctx_switches_vol INTEGER, ctx_switches_inv INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_%[1]s_pid_ts ON %[1]s(pid, ts);
+`, name)
+	}
+	schema := tier("raw_samples") + tier("minute_samples") + tier("hour_samples")
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("store: migrate: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) WriteSample(stats ResourceStats) error {
+	perCPU, err := json.Marshal(stats.PerCPUPercent)
+	if err != nil {
+		return fmt.Errorf("store: marshal per_cpu_percent: %w", err)
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO raw_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		stats.PID, stats.ProcessName, stats.Timestamp.Unix(), stats.CPUPercent, string(perCPU), stats.MemoryMB,
+		stats.RSSBytes, stats.VMSBytes, stats.SharedBytes, stats.NumThreads, stats.NumFDs,
+		stats.IOReadBytes, stats.IOWriteBytes, stats.CtxSwitchesVol, stats.CtxSwitchesInv,
+	)
+	return err
+}
+
+// Query picks the coarsest tier that still covers [from, to] at the
+// requested step, then takes one row per step-sized bucket.
+func (s *sqliteStore) Query(pid int32, from, to time.Time, step time.Duration) ([]ResourceStats, error) {
+	table := "raw_samples"
+	switch {
+	case to.Sub(from) > minuteRetention:
+		table = "hour_samples"
+	case to.Sub(from) > rawRetention:
+		table = "minute_samples"
+	}
+
+	rows, err := s.db.Query(
+		fmt.Sprintf(`SELECT %s FROM %s WHERE pid = ? AND ts >= ? AND ts <= ? ORDER BY ts ASC`, sampleColumns, table),
+		pid, from.Unix(), to.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store: query: %w", err)
+	}
+	defer rows.Close()
+
+	bucketSeconds := int64(step.Seconds())
+	if bucketSeconds <= 0 {
+		bucketSeconds = 1
+	}
+
+	var out []ResourceStats
+	lastBucket := int64(-1)
+	for rows.Next() {
+		var stats ResourceStats
+		var rowPID int32
+		var ts int64
+		var perCPU string
+		if err := rows.Scan(
+			&rowPID, &stats.ProcessName, &ts, &stats.CPUPercent, &perCPU, &stats.MemoryMB,
+			&stats.RSSBytes, &stats.VMSBytes, &stats.SharedBytes, &stats.NumThreads, &stats.NumFDs,
+			&stats.IOReadBytes, &stats.IOWriteBytes, &stats.CtxSwitchesVol, &stats.CtxSwitchesInv,
+		); err != nil {
+			return nil, err
+		}
+		bucket := ts / bucketSeconds
+		if bucket == lastBucket {
+			continue
+		}
+		lastBucket = bucket
+		if perCPU != "" {
+			json.Unmarshal([]byte(perCPU), &stats.PerCPUPercent)
+		}
+		stats.PID = rowPID
+		stats.Timestamp = time.Unix(ts, 0)
+		out = append(out, stats)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) Close() error { return s.db.Close() }
+
+// compact rolls aged raw samples up into 1-minute averages, aged minute
+// samples into 1-hour averages, then prunes rows past each tier's
+// retention window. Rollups only ever touch rows older than the
+// retention cutoff, so a row is migrated exactly once before deletion.
+func (s *sqliteStore) compact() error {
+	now := time.Now()
+	rawCutoff := now.Add(-rawRetention).Unix()
+	minuteCutoff := now.Add(-minuteRetention).Unix()
+	hourCutoff := now.Add(-hourRetention).Unix()
+
+	if _, err := s.db.Exec(
+		`INSERT INTO minute_samples (`+sampleColumns+`)
+		 SELECT pid, process_name, (ts / 60) * 60, AVG(cpu_percent), '[]', AVG(memory_mb),
+		        AVG(rss_bytes), AVG(vms_bytes), AVG(shared_bytes), AVG(num_threads), AVG(num_fds),
+		        MAX(io_read_bytes), MAX(io_write_bytes), MAX(ctx_switches_vol), MAX(ctx_switches_inv)
+		 FROM raw_samples WHERE ts < ? GROUP BY pid, ts / 60`, rawCutoff); err != nil {
+		return fmt.Errorf("store: compact raw->minute: %w", err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM raw_samples WHERE ts < ?`, rawCutoff); err != nil {
+		return fmt.Errorf("store: prune raw: %w", err)
+	}
+
+	if _, err := s.db.Exec(
+		`INSERT INTO hour_samples (`+sampleColumns+`)
+		 SELECT pid, process_name, (ts / 3600) * 3600, AVG(cpu_percent), '[]', AVG(memory_mb),
+		        AVG(rss_bytes), AVG(vms_bytes), AVG(shared_bytes), AVG(num_threads), AVG(num_fds),
+		        MAX(io_read_bytes), MAX(io_write_bytes), MAX(ctx_switches_vol), MAX(ctx_switches_inv)
+		 FROM minute_samples WHERE ts < ? GROUP BY pid, ts / 3600`, minuteCutoff); err != nil {
+		return fmt.Errorf("store: compact minute->hour: %w", err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM minute_samples WHERE ts < ?`, minuteCutoff); err != nil {
+		return fmt.Errorf("store: prune minute: %w", err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM hour_samples WHERE ts < ?`, hourCutoff); err != nil {
+		return fmt.Errorf("store: prune hour: %w", err)
+	}
+	return nil
+}
+
+// startCompactor runs compact on a fixed interval until the process exits.
+func (s *sqliteStore) startCompactor() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.compact(); err != nil {
+				log.Printf("Store compaction failed: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// insertRawSample inserts directly into raw_samples with an explicit ts,
+// bypassing WriteSample's time.Now() stamping so tests can place rows on
+// either side of a retention cutoff deterministically.
+func insertRawSample(t *testing.T, s *sqliteStore, pid int32, ts time.Time, cpu, mem float64) {
+	t.Helper()
+	_, err := s.db.Exec(
+		`INSERT INTO raw_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		pid, "proc", ts.Unix(), cpu, "[]", mem, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	)
+	if err != nil {
+		t.Fatalf("insertRawSample: %v", err)
+	}
+}
+
+func countRows(t *testing.T, s *sqliteStore, table string) int {
+	t.Helper()
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("countRows(%s): %v", table, err)
+	}
+	return n
+}
+
+func newTestSQLiteStore(t *testing.T) *sqliteStore {
+	t.Helper()
+	s, err := newSQLiteStore(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatalf("newSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteStoreCompactRawToMinuteCutoff(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	now := time.Now()
+
+	aged := now.Add(-rawRetention - time.Minute)
+	fresh := now.Add(-rawRetention + time.Minute)
+	insertRawSample(t, s, 1, aged, 42, 100)
+	insertRawSample(t, s, 1, fresh, 10, 50)
+
+	if err := s.compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	if got := countRows(t, s, "raw_samples"); got != 1 {
+		t.Errorf("raw_samples rows after compact = %d, want 1 (only the fresh sample survives)", got)
+	}
+	if got := countRows(t, s, "minute_samples"); got != 1 {
+		t.Errorf("minute_samples rows after compact = %d, want 1 (the aged sample rolled up)", got)
+	}
+}
+
+func TestSQLiteStoreCompactMinuteToHourCutoff(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	now := time.Now()
+
+	aged := now.Add(-minuteRetention - time.Minute)
+	fresh := now.Add(-minuteRetention + time.Minute)
+	if _, err := s.db.Exec(
+		`INSERT INTO minute_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", aged.Unix(), 42.0, "[]", 100.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert aged minute sample: %v", err)
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO minute_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", fresh.Unix(), 10.0, "[]", 50.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert fresh minute sample: %v", err)
+	}
+
+	if err := s.compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	if got := countRows(t, s, "minute_samples"); got != 1 {
+		t.Errorf("minute_samples rows after compact = %d, want 1 (only the fresh sample survives)", got)
+	}
+	if got := countRows(t, s, "hour_samples"); got != 1 {
+		t.Errorf("hour_samples rows after compact = %d, want 1 (the aged sample rolled up)", got)
+	}
+}
+
+func TestSQLiteStoreCompactPrunesExpiredHourSamples(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	now := time.Now()
+
+	expired := now.Add(-hourRetention - time.Hour)
+	kept := now.Add(-hourRetention + time.Hour)
+	if _, err := s.db.Exec(
+		`INSERT INTO hour_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", expired.Unix(), 1.0, "[]", 1.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert expired hour sample: %v", err)
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO hour_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", kept.Unix(), 1.0, "[]", 1.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert kept hour sample: %v", err)
+	}
+
+	if err := s.compact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	if got := countRows(t, s, "hour_samples"); got != 1 {
+		t.Errorf("hour_samples rows after compact = %d, want 1 (the expired row should be pruned outright)", got)
+	}
+}
+
+func TestSQLiteStoreQueryTierSelection(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	ts := time.Unix(1_700_000_000, 0)
+	insertRawSample(t, s, 1, ts, 1, 1)
+	if _, err := s.db.Exec(
+		`INSERT INTO minute_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", ts.Unix(), 2.0, "[]", 2.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert minute sample: %v", err)
+	}
+	if _, err := s.db.Exec(
+		`INSERT INTO hour_samples (`+sampleColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		int32(1), "proc", ts.Unix(), 3.0, "[]", 3.0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	); err != nil {
+		t.Fatalf("insert hour sample: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		window   time.Duration
+		wantCPU  float64
+	}{
+		{"window at raw retention boundary queries raw", rawRetention, 1},
+		{"window just past raw retention queries minute", rawRetention + time.Second, 2},
+		{"window at minute retention boundary queries minute", minuteRetention, 2},
+		{"window just past minute retention queries hour", minuteRetention + time.Second, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := ts.Add(-tt.window / 2)
+			to := ts.Add(tt.window / 2)
+			series, err := s.Query(1, from, to, time.Second)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if len(series) != 1 {
+				t.Fatalf("got %d rows, want 1", len(series))
+			}
+			if series[0].CPUPercent != tt.wantCPU {
+				t.Errorf("CPUPercent = %v, want %v (wrong tier queried)", series[0].CPUPercent, tt.wantCPU)
+			}
+		})
+	}
+}
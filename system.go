@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	gopsutilload "github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// NICStats is per-interface RX/TX, kept separate instead of summed so a
+// busy wlan0 can't hide behind an idle lo, and vice versa.
+type NICStats struct {
+	Name      string `json:"name"`
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	TxPackets uint64 `json:"tx_packets"`
+}
+
+// DiskStats is per-mountpoint usage and cumulative IO counters.
+type DiskStats struct {
+	Mountpoint string  `json:"mountpoint"`
+	TotalBytes uint64  `json:"total_bytes"`
+	UsedBytes  uint64  `json:"used_bytes"`
+	FreeBytes  uint64  `json:"free_bytes"`
+	UsedPct    float64 `json:"used_percent"`
+	ReadBytes  uint64  `json:"read_bytes"`
+	WriteBytes uint64  `json:"write_bytes"`
+}
+
+// SystemStats is a node-exporter-style snapshot of host-wide state,
+// meant to be graphed alongside the per-process ResourceStats.
+type SystemStats struct {
+	Timestamp   time.Time   `json:"timestamp"`
+	Load1       float64     `json:"load1"`
+	Load5       float64     `json:"load5"`
+	Load15      float64     `json:"load15"`
+	MemTotal    uint64      `json:"mem_total_bytes"`
+	MemUsed     uint64      `json:"mem_used_bytes"`
+	MemFree     uint64      `json:"mem_free_bytes"`
+	MemCached   uint64      `json:"mem_cached_bytes"`
+	SwapTotal   uint64      `json:"swap_total_bytes"`
+	SwapUsed    uint64      `json:"swap_used_bytes"`
+	Disks       []DiskStats `json:"disks"`
+	Interfaces  []NICStats  `json:"interfaces"`
+}
+
+var (
+	systemStats      SystemStats
+	systemStatsMutex sync.RWMutex
+)
+
+// collectSystemStats takes a single host-wide snapshot.
+func collectSystemStats() (SystemStats, error) {
+	stats := SystemStats{Timestamp: time.Now()}
+
+	if avg, err := gopsutilload.Avg(); err == nil {
+		stats.Load1, stats.Load5, stats.Load15 = avg.Load1, avg.Load5, avg.Load15
+	}
+
+	if vm, err := mem.VirtualMemory(); err == nil {
+		stats.MemTotal = vm.Total
+		stats.MemUsed = vm.Used
+		stats.MemFree = vm.Free
+		stats.MemCached = vm.Cached
+	}
+	if sw, err := mem.SwapMemory(); err == nil {
+		stats.SwapTotal = sw.Total
+		stats.SwapUsed = sw.Used
+	}
+
+	if parts, err := disk.Partitions(false); err == nil {
+		ioCounters, _ := disk.IOCounters()
+		for _, p := range parts {
+			usage, err := disk.Usage(p.Mountpoint)
+			if err != nil {
+				continue
+			}
+			ds := DiskStats{
+				Mountpoint: p.Mountpoint,
+				TotalBytes: usage.Total,
+				UsedBytes:  usage.Used,
+				FreeBytes:  usage.Free,
+				UsedPct:    usage.UsedPercent,
+			}
+			if io, ok := ioCounters[p.Device]; ok {
+				ds.ReadBytes = io.ReadBytes
+				ds.WriteBytes = io.WriteBytes
+			}
+			stats.Disks = append(stats.Disks, ds)
+		}
+	}
+
+	if counters, err := net.IOCounters(true); err == nil {
+		for _, c := range counters {
+			stats.Interfaces = append(stats.Interfaces, NICStats{
+				Name:      c.Name,
+				RxBytes:   c.BytesRecv,
+				TxBytes:   c.BytesSent,
+				RxPackets: c.PacketsRecv,
+				TxPackets: c.PacketsSent,
+			})
+		}
+	}
+
+	return stats, nil
+}
+
+// systemMonitor periodically refreshes systemStats in the background,
+// mirroring the cadence monitorProcess uses for per-PID sampling.
+func systemMonitor() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats, err := collectSystemStats()
+		if err != nil {
+			log.Printf("Failed to collect system stats: %v", err)
+			continue
+		}
+		systemStatsMutex.Lock()
+		systemStats = stats
+		systemStatsMutex.Unlock()
+	}
+}
+
+func systemHandler(w http.ResponseWriter, r *http.Request) {
+	systemStatsMutex.RLock()
+	defer systemStatsMutex.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(systemStats)
+}